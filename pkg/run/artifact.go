@@ -0,0 +1,439 @@
+package run
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Artifact describes a remote file or archive that Run fetches and
+// places into a task's directory before Start is called.
+type Artifact struct {
+	// Source is the location to fetch from. The scheme selects the
+	// [Getter] used to retrieve it: "http://" and "https://" are
+	// built in; "git::" and "s3://" require a [Getter] registered with
+	// RegisterGetter.
+	Source string
+
+	// Dest is the path, relative to the task's directory, the artifact
+	// is materialized at.
+	Dest string
+
+	// Checksum, if set, is "algo:hex" (for example
+	// "sha256:abc123..."). Only the "sha256" algorithm is supported; a
+	// Checksum naming any other is rejected as malformed. Run resolves
+	// the Source into its content-addressed cache under
+	// "./.run/artifacts/<sha256>/", verifies this checksum, and fails the
+	// task rather than place unverified content.
+	Checksum string
+
+	// Mode selects how the fetched content is materialized at Dest:
+	// "file" (the default, a single hardlinked or copied file), "dir"
+	// (a directory copied as-is), or "archive" (extracted, detecting
+	// tar, tgz/tar.gz, and zip by Source's extension).
+	Mode string
+}
+
+// Getter fetches the content named by src and writes it to dst, a path
+// within the artifact cache. RegisterGetter plugs additional schemes
+// (git, object stores) in alongside the built-in http(s) and file
+// getters.
+type Getter interface {
+	Fetch(ctx context.Context, src, dst string) error
+}
+
+var getters = map[string]Getter{
+	"http":  httpGetter{},
+	"https": httpGetter{},
+	"file":  fileGetter{},
+}
+
+// RegisterGetter registers g as the [Getter] used for Source URLs with
+// the given scheme (the part before "://", or before "::" for
+// "git::..." style sources). Registering a scheme that is already
+// registered replaces the existing Getter.
+func RegisterGetter(scheme string, g Getter) {
+	getters[scheme] = g
+}
+
+func scheme(src string) string {
+	if i := strings.Index(src, "::"); i >= 0 {
+		return src[:i]
+	}
+	if i := strings.Index(src, "://"); i >= 0 {
+		return src[:i]
+	}
+	return ""
+}
+
+type httpGetter struct{}
+
+func (httpGetter) Fetch(ctx context.Context, src, dst string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch %s: unexpected status %s", src, resp.Status)
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, resp.Body)
+	return err
+}
+
+type fileGetter struct{}
+
+func (fileGetter) Fetch(ctx context.Context, src, dst string) error {
+	src = strings.TrimPrefix(src, "file://")
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// artifactCacheRoot is the directory, relative to the working directory,
+// under which fetched artifacts are content-addressed.
+const artifactCacheRoot = ".run/artifacts"
+
+// dirTask is implemented by Task types that run in a directory of their
+// own, so RunTask knows where to materialize their TaskMetadata.Artifacts
+// before Start is called. scriptTask implements it; a FuncTask has no
+// directory of its own and Artifacts are resolved relative to the
+// working directory instead.
+type dirTask interface {
+	Dir() string
+}
+
+// resolveArtifacts fetches each of the given artifacts into the shared
+// content-addressed cache, verifies its checksum, and materializes it at
+// dir/Dest, extracting it first if Mode is "archive".
+func resolveArtifacts(ctx context.Context, dir string, artifacts []Artifact) error {
+	for _, a := range artifacts {
+		if err := resolveArtifact(ctx, dir, a); err != nil {
+			return fmt.Errorf("artifact %s: %w", a.Source, err)
+		}
+	}
+	return nil
+}
+
+func resolveArtifact(ctx context.Context, dir string, a Artifact) error {
+	sch := scheme(a.Source)
+	getter, ok := getters[sch]
+	if !ok {
+		return fmt.Errorf("no Getter registered for scheme %q", sch)
+	}
+
+	cached, err := fetchContentAddressed(ctx, getter, a)
+	if err != nil {
+		return err
+	}
+
+	dest := filepath.Join(dir, a.Dest)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+
+	switch a.Mode {
+	case "", "file":
+		return linkOrCopy(cached, dest)
+	case "dir":
+		return copyDir(cached, dest)
+	case "archive":
+		return extractArchive(cached, a.Source, dest)
+	default:
+		return fmt.Errorf("unknown Mode %q", a.Mode)
+	}
+}
+
+// fetchContentAddressed resolves a into the shared cache under
+// artifactCacheRoot, keyed by the fetched content's own sha256 hash
+// rather than a.Source, and returns the path to the cached content.
+//
+// If a.Checksum names the expected sha256, and an entry already exists
+// under that hash, it's reused without re-fetching. Otherwise a is
+// always fetched to a temporary file first: its content is hashed (and
+// checked against a.Checksum, if set) before it's moved into the cache,
+// so a Source whose upstream content changed without its URL changing
+// is never served stale from a previous run, and unverified content is
+// never trusted.
+func fetchContentAddressed(ctx context.Context, getter Getter, a Artifact) (string, error) {
+	if key, ok := checksumCacheKey(a.Checksum); ok {
+		candidate := filepath.Join(artifactCacheRoot, key, "content")
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+
+	tmp, err := fetchToTemp(ctx, getter, a.Source)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp)
+
+	key, err := contentHash(tmp)
+	if err != nil {
+		return "", err
+	}
+	if a.Checksum != "" {
+		wantKey, ok := checksumCacheKey(a.Checksum)
+		if !ok {
+			return "", fmt.Errorf("malformed Checksum %q, want \"sha256:hex\"", a.Checksum)
+		}
+		if key != wantKey {
+			return "", fmt.Errorf("checksum mismatch: want %s, got %s", wantKey, key)
+		}
+	}
+
+	cacheDir := filepath.Join(artifactCacheRoot, key)
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return "", err
+	}
+	cached := filepath.Join(cacheDir, "content")
+	if _, err := os.Stat(cached); os.IsNotExist(err) {
+		if err := os.Rename(tmp, cached); err != nil {
+			return "", err
+		}
+	}
+	return cached, nil
+}
+
+// checksumCacheKey parses checksum as "sha256:hex", returning its hex
+// digest and true. Any other algorithm, or a malformed checksum, returns
+// false; fetchContentAddressed then skips the cache lookup and, after
+// fetching, rejects the Checksum as malformed.
+func checksumCacheKey(checksum string) (string, bool) {
+	parts := strings.SplitN(checksum, ":", 2)
+	if len(parts) != 2 || parts[0] != "sha256" || parts[1] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// fetchToTemp fetches src via getter into a new temporary file under
+// artifactCacheRoot, so the eventual os.Rename into the content-addressed
+// cache is same-filesystem and atomic. The caller is responsible for
+// removing the returned path.
+func fetchToTemp(ctx context.Context, getter Getter, src string) (string, error) {
+	if err := os.MkdirAll(artifactCacheRoot, 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(artifactCacheRoot, "fetch-*")
+	if err != nil {
+		return "", err
+	}
+	tmp := f.Name()
+	f.Close()
+
+	if err := getter.Fetch(ctx, src, tmp); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
+
+// contentHash returns the hex-encoded sha256 of the file at path.
+func contentHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func linkOrCopy(src, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
+
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		return linkOrCopy(path, target)
+	})
+}
+
+// extractArchive extracts the archive at src into dst, detecting
+// tar.gz/tgz, tar, and zip by name's extension.
+func extractArchive(src, name, dst string) error {
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return extractTarGz(src, dst)
+	case strings.HasSuffix(name, ".tar"):
+		return extractTar(src, dst)
+	case strings.HasSuffix(name, ".zip"):
+		return extractZip(src, dst)
+	default:
+		return fmt.Errorf("cannot detect archive type from name %q", name)
+	}
+}
+
+func extractTarGz(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	return extractTarReader(tar.NewReader(gz), dst)
+}
+
+func extractTar(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return extractTarReader(tar.NewReader(f), dst)
+}
+
+// safeJoin joins dst and name, the path of an archive entry, and returns
+// an error if the result would escape dst: an archive fetched from an
+// arbitrary, possibly attacker-influenced Source must never be allowed
+// to write outside the task directory via a "../" or absolute entry
+// name (a "zip slip").
+func safeJoin(dst, name string) (string, error) {
+	target := filepath.Join(dst, name)
+	rel, err := filepath.Rel(dst, target)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry %q escapes destination %q", name, dst)
+	}
+	return target, nil
+}
+
+func extractTarReader(tr *tar.Reader, dst string) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := safeJoin(dst, hdr.Name)
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			out, err := os.Create(target)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			out.Close()
+		}
+	}
+}
+
+func extractZip(src, dst string) error {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		target, err := safeJoin(dst, f.Name)
+		if err != nil {
+			return err
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		out, err := os.Create(target)
+		if err != nil {
+			rc.Close()
+			return err
+		}
+		_, err = io.Copy(out, rc)
+		rc.Close()
+		out.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package run
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveArtifactsPlacesFile(t *testing.T) {
+	chdirTemp(t)
+	srcDir := t.TempDir()
+	src := filepath.Join(srcDir, "data.txt")
+	require.NoError(t, os.WriteFile(src, []byte("hello"), 0o644))
+
+	destDir := t.TempDir()
+	a := Artifact{Source: "file://" + src, Dest: "out.txt"}
+	require.NoError(t, resolveArtifacts(context.Background(), destDir, []Artifact{a}))
+
+	got, err := os.ReadFile(filepath.Join(destDir, "out.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(got))
+}
+
+func TestResolveArtifactsVerifiesChecksum(t *testing.T) {
+	chdirTemp(t)
+	srcDir := t.TempDir()
+	src := filepath.Join(srcDir, "data.txt")
+	require.NoError(t, os.WriteFile(src, []byte("hello"), 0o644))
+
+	destDir := t.TempDir()
+	correct := Artifact{
+		Source:   "file://" + src,
+		Dest:     "out.txt",
+		Checksum: "sha256:" + mustHashBytes(t, []byte("hello")),
+	}
+	require.NoError(t, resolveArtifacts(context.Background(), destDir, []Artifact{correct}))
+
+	mismatched := Artifact{
+		Source:   "file://" + src,
+		Dest:     "out2.txt",
+		Checksum: "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+	}
+	err := resolveArtifacts(context.Background(), destDir, []Artifact{mismatched})
+	assert.Error(t, err)
+}
+
+func TestResolveArtifactsCachesByContentNotSource(t *testing.T) {
+	chdirTemp(t)
+	srcDir := t.TempDir()
+	srcA := filepath.Join(srcDir, "a.txt")
+	srcB := filepath.Join(srcDir, "b.txt")
+	require.NoError(t, os.WriteFile(srcA, []byte("same content"), 0o644))
+	require.NoError(t, os.WriteFile(srcB, []byte("same content"), 0o644))
+
+	destDir := t.TempDir()
+	require.NoError(t, resolveArtifacts(context.Background(), destDir, []Artifact{{Source: "file://" + srcA, Dest: "a"}}))
+	require.NoError(t, resolveArtifacts(context.Background(), destDir, []Artifact{{Source: "file://" + srcB, Dest: "b"}}))
+
+	entries, err := os.ReadDir(artifactCacheRoot)
+	require.NoError(t, err)
+	var dirs int
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs++
+		}
+	}
+	assert.Equal(t, 1, dirs, "two sources with identical content should share one content-addressed cache entry")
+
+	// Changing the content behind srcA must not reuse the stale cache
+	// entry, since the key is the content hash, not the source URL.
+	require.NoError(t, os.WriteFile(srcA, []byte("different content"), 0o644))
+	require.NoError(t, resolveArtifacts(context.Background(), destDir, []Artifact{{Source: "file://" + srcA, Dest: "a2"}}))
+	got, err := os.ReadFile(filepath.Join(destDir, "a2"))
+	require.NoError(t, err)
+	assert.Equal(t, "different content", string(got))
+}
+
+func mustHashBytes(t *testing.T, data []byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tmp")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	h, err := contentHash(path)
+	require.NoError(t, err)
+	return h
+}
+
+func TestExtractArchiveUnknownExtension(t *testing.T) {
+	err := extractArchive("/nonexistent", "thing.unknownext", t.TempDir())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot detect")
+}
+
+func TestExtractZipRejectsPathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "evil.zip")
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("../../../etc/passwd")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("pwned"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, os.WriteFile(zipPath, buf.Bytes(), 0o644))
+
+	err = extractZip(zipPath, t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestExtractTarRejectsPathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	tarPath := filepath.Join(dir, "evil.tar")
+
+	f, err := os.Create(tarPath)
+	require.NoError(t, err)
+	tw := tar.NewWriter(f)
+	content := []byte("pwned")
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name: "../../../etc/passwd",
+		Mode: 0o600,
+		Size: int64(len(content)),
+	}))
+	_, err = tw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, f.Close())
+
+	err = extractTar(tarPath, t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestExtractZipAcceptsWellBehavedArchive(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "good.zip")
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("nested/file.txt")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("ok"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, os.WriteFile(zipPath, buf.Bytes(), 0o644))
+
+	dst := t.TempDir()
+	require.NoError(t, extractZip(zipPath, dst))
+
+	got, err := os.ReadFile(filepath.Join(dst, "nested", "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(got))
+}
@@ -0,0 +1,179 @@
+package run
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// cacheRoot is the directory, relative to the working directory, under
+// which per-task fingerprint logs are kept.
+const cacheRoot = ".run/cache"
+
+// FingerprintContributor is implemented by Task types that want to fold
+// extra bytes into their cache fingerprint, beyond their TaskMetadata and
+// resolved Inputs. scriptTask implements it to include its script body,
+// so that editing the command invalidates the cache even though no
+// watched file changed.
+type FingerprintContributor interface {
+	FingerprintBytes() []byte
+}
+
+// fingerprint computes the SHA-256 fingerprint used to decide whether a
+// short task's last successful run is still valid: the task ID, the
+// sorted resolved Inputs paths with their content hashes, the observed
+// values of TaskMetadata.FingerprintEnv, each Artifact's Source and
+// Checksum, and any bytes contributed by the task via
+// FingerprintContributor.
+func fingerprint(t Task) (string, error) {
+	meta := t.Metadata()
+
+	paths, err := resolveGlobs(meta.Inputs)
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	h.Write([]byte(meta.ID))
+
+	for _, p := range paths {
+		contents, err := os.ReadFile(p)
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256(contents)
+		h.Write([]byte(p))
+		h.Write(sum[:])
+	}
+
+	envNames := append([]string(nil), meta.FingerprintEnv...)
+	sort.Strings(envNames)
+	for _, name := range envNames {
+		h.Write([]byte(name))
+		h.Write([]byte("="))
+		h.Write([]byte(os.Getenv(name)))
+	}
+
+	for _, a := range meta.Artifacts {
+		h.Write([]byte(a.Source))
+		h.Write([]byte(a.Checksum))
+	}
+
+	if fc, ok := t.(FingerprintContributor); ok {
+		h.Write(fc.FingerprintBytes())
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// resolveGlobs expands a list of Watch-style glob patterns into a
+// deduplicated list of matching file paths.
+func resolveGlobs(patterns []string) ([]string, error) {
+	seen := map[string]bool{}
+	var out []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			if seen[m] {
+				continue
+			}
+			seen[m] = true
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// cacheEntry is the on-disk record of a task's last execution, used to
+// decide whether a later invocation can be skipped.
+type cacheEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Success     bool   `json:"success"`
+}
+
+func cacheDir(id string) string {
+	return filepath.Join(cacheRoot, strings.ReplaceAll(id, "/", "_"))
+}
+
+func cacheFile(id string) string {
+	return filepath.Join(cacheDir(id), "entry.json")
+}
+
+func readCacheEntry(id string) (cacheEntry, bool) {
+	data, err := os.ReadFile(cacheFile(id))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+func writeCacheEntry(id string, e cacheEntry) error {
+	dir := cacheDir(id)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cacheFile(id), data, 0o644)
+}
+
+// shouldSkip reports whether t's Inputs are unchanged, content-wise,
+// since its last successful execution, meaning Run can skip calling
+// Start and print "(cached)" in its place. A task with neither Inputs
+// nor Artifacts is never skipped.
+func shouldSkip(t Task) (bool, error) {
+	meta := t.Metadata()
+	if len(meta.Inputs) == 0 && len(meta.Artifacts) == 0 {
+		return false, nil
+	}
+	fp, err := fingerprint(t)
+	if err != nil {
+		return false, err
+	}
+	entry, ok := readCacheEntry(meta.ID)
+	if !ok {
+		return false, nil
+	}
+	return entry.Success && entry.Fingerprint == fp, nil
+}
+
+// recordResult persists the outcome of a task's execution so a future
+// shouldSkip call can consult it.
+func recordResult(t Task, runErr error) error {
+	meta := t.Metadata()
+	if len(meta.Inputs) == 0 && len(meta.Artifacts) == 0 {
+		return nil
+	}
+	fp, err := fingerprint(t)
+	if err != nil {
+		return err
+	}
+	return writeCacheEntry(meta.ID, cacheEntry{Fingerprint: fp, Success: runErr == nil})
+}
+
+// ClearCache removes the persisted fingerprint log for the task with the
+// given ID, forcing its next scheduled execution to run rather than be
+// skipped as "(cached)". ClearCache is a no-op if no cache entry exists
+// for id. Nothing in this package calls ClearCache automatically; it's
+// for a caller to invoke explicitly, e.g. after a Watch-triggered change
+// that may not be reflected in Inputs.
+func (ts Tasks) ClearCache(id string) error {
+	if err := os.RemoveAll(cacheDir(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubTask is a minimal Task used to exercise fingerprint/shouldSkip/
+// recordResult without depending on a concrete scriptTask or funcTask.
+type stubTask struct {
+	meta TaskMetadata
+	fp   []byte
+}
+
+func (s stubTask) Start(ctx context.Context, stdout io.Writer) error { return nil }
+func (s stubTask) Metadata() TaskMetadata                            { return s.meta }
+func (s stubTask) FingerprintBytes() []byte                          { return s.fp }
+
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestFingerprintChangesWithInputContent(t *testing.T) {
+	dir := chdirTemp(t)
+	file := filepath.Join(dir, "in.txt")
+	require.NoError(t, os.WriteFile(file, []byte("v1"), 0o644))
+
+	task := stubTask{meta: TaskMetadata{ID: "build", Inputs: []string{file}}}
+	fp1, err := fingerprint(task)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(file, []byte("v2"), 0o644))
+	fp2, err := fingerprint(task)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, fp1, fp2)
+}
+
+func TestFingerprintChangesWithEnvAndContributor(t *testing.T) {
+	t.Setenv("RUN_TEST_FINGERPRINT_VAR", "a")
+
+	task := stubTask{
+		meta: TaskMetadata{ID: "build", FingerprintEnv: []string{"RUN_TEST_FINGERPRINT_VAR"}},
+		fp:   []byte("script-a"),
+	}
+	fp1, err := fingerprint(task)
+	require.NoError(t, err)
+
+	t.Setenv("RUN_TEST_FINGERPRINT_VAR", "b")
+	fp2, err := fingerprint(task)
+	require.NoError(t, err)
+	assert.NotEqual(t, fp1, fp2, "changing a FingerprintEnv var should change the fingerprint")
+
+	t.Setenv("RUN_TEST_FINGERPRINT_VAR", "a")
+	task.fp = []byte("script-b")
+	fp3, err := fingerprint(task)
+	require.NoError(t, err)
+	assert.NotEqual(t, fp1, fp3, "changing FingerprintContributor bytes should change the fingerprint")
+}
+
+func TestShouldSkipCacheHitAndMiss(t *testing.T) {
+	dir := chdirTemp(t)
+	file := filepath.Join(dir, "in.txt")
+	require.NoError(t, os.WriteFile(file, []byte("v1"), 0o644))
+
+	task := stubTask{meta: TaskMetadata{ID: "build", Inputs: []string{file}}}
+
+	skip, err := shouldSkip(task)
+	require.NoError(t, err)
+	assert.False(t, skip, "no prior run recorded yet")
+
+	require.NoError(t, recordResult(task, nil))
+
+	skip, err = shouldSkip(task)
+	require.NoError(t, err)
+	assert.True(t, skip, "Inputs unchanged since last success")
+
+	require.NoError(t, os.WriteFile(file, []byte("v2"), 0o644))
+	skip, err = shouldSkip(task)
+	require.NoError(t, err)
+	assert.False(t, skip, "Inputs changed since last success")
+}
+
+func TestShouldSkipAfterFailureDoesNotSkip(t *testing.T) {
+	dir := chdirTemp(t)
+	file := filepath.Join(dir, "in.txt")
+	require.NoError(t, os.WriteFile(file, []byte("v1"), 0o644))
+
+	task := stubTask{meta: TaskMetadata{ID: "build", Inputs: []string{file}}}
+	require.NoError(t, recordResult(task, errors.New("boom")))
+
+	skip, err := shouldSkip(task)
+	require.NoError(t, err)
+	assert.False(t, skip, "a failed run must never be treated as cached")
+}
+
+func TestTasksClearCache(t *testing.T) {
+	dir := chdirTemp(t)
+	file := filepath.Join(dir, "in.txt")
+	require.NoError(t, os.WriteFile(file, []byte("v1"), 0o644))
+
+	task := stubTask{meta: TaskMetadata{ID: "build", Inputs: []string{file}}}
+	require.NoError(t, recordResult(task, nil))
+
+	ts := NewTasks([]Task{task})
+	require.NoError(t, ts.ClearCache("build"))
+
+	skip, err := shouldSkip(task)
+	require.NoError(t, err)
+	assert.False(t, skip, "ClearCache must force the next run")
+
+	assert.NoError(t, ts.ClearCache("build"), "clearing an already-clear cache is a no-op")
+}
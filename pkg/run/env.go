@@ -0,0 +1,92 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// envContextKey is the context.Context key under which a task's merged
+// environment is stored for retrieval via EnvFromContext.
+type envContextKey struct{}
+
+// EnvFromContext returns the environment variables merged for the
+// current task invocation: TaskMetadata.Env layered over the enclosing
+// tasks file's top-level [env] table. FuncTask implementations read this
+// instead of os.Environ when they need the task's configured
+// environment.
+//
+// EnvFromContext returns a nil map if Run did not attach an environment
+// to ctx.
+func EnvFromContext(ctx context.Context) map[string]string {
+	env, _ := ctx.Value(envContextKey{}).(map[string]string)
+	return env
+}
+
+// contextWithEnv returns a copy of ctx carrying env, so that a FuncTask
+// started with it can retrieve the merged environment via
+// EnvFromContext.
+func contextWithEnv(ctx context.Context, env map[string]string) context.Context {
+	return context.WithValue(ctx, envContextKey{}, env)
+}
+
+// mergeEnv layers override's entries on top of base, returning a new map
+// and leaving both inputs untouched. It is used to merge a tasks file's
+// [env] table into a child tasks file's, and a tasks file's [env] table
+// into a single task's Env.
+func mergeEnv(base, override map[string]string) map[string]string {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+// interpolationPattern matches ${VAR} references.
+var interpolationPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// interpolate replaces ${VAR} references in s with values from env,
+// returning an error naming the first undefined variable so Load can
+// return it as a load-time error.
+func interpolate(s string, env map[string]string) (string, error) {
+	var firstErr error
+	out := interpolationPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if firstErr != nil {
+			return match
+		}
+		name := interpolationPattern.FindStringSubmatch(match)[1]
+		val, ok := env[name]
+		if !ok {
+			firstErr = fmt.Errorf("undefined variable %q in %q", name, s)
+			return match
+		}
+		return val
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return out, nil
+}
+
+// interpolateAll runs interpolate over each string in ss, returning the
+// first error encountered.
+func interpolateAll(ss []string, env map[string]string) ([]string, error) {
+	if ss == nil {
+		return nil, nil
+	}
+	out := make([]string, len(ss))
+	for i, s := range ss {
+		v, err := interpolate(s, env)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,54 @@
+package run
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolateSubstitutes(t *testing.T) {
+	env := map[string]string{"NAME": "world"}
+	out, err := interpolate("hello ${NAME}", env)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", out)
+}
+
+func TestInterpolateUndefinedVariableErrors(t *testing.T) {
+	_, err := interpolate("hello ${MISSING}", map[string]string{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "MISSING")
+}
+
+func TestInterpolateAllPreservesNil(t *testing.T) {
+	out, err := interpolateAll(nil, map[string]string{"X": "y"})
+	require.NoError(t, err)
+	assert.Nil(t, out)
+}
+
+func TestInterpolateAllStopsAtFirstError(t *testing.T) {
+	_, err := interpolateAll([]string{"${OK}", "${MISSING}"}, map[string]string{"OK": "fine"})
+	assert.Error(t, err)
+}
+
+func TestMergeEnvOverrideWins(t *testing.T) {
+	base := map[string]string{"A": "base", "B": "base"}
+	override := map[string]string{"B": "override"}
+	merged := mergeEnv(base, override)
+	assert.Equal(t, map[string]string{"A": "base", "B": "override"}, merged)
+}
+
+func TestMergeEnvEmptyReturnsNil(t *testing.T) {
+	assert.Nil(t, mergeEnv(nil, nil))
+}
+
+func TestEnvFromContextRoundTrip(t *testing.T) {
+	env := map[string]string{"FOO": "bar"}
+	ctx := contextWithEnv(context.Background(), env)
+	assert.Equal(t, env, EnvFromContext(ctx))
+}
+
+func TestEnvFromContextNilWhenUnset(t *testing.T) {
+	assert.Nil(t, EnvFromContext(context.Background()))
+}
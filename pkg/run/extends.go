@@ -0,0 +1,82 @@
+package run
+
+import "fmt"
+
+// extendable is implemented by the raw, not-yet-constructed task
+// representation the TOML loader builds while parsing a tasks file. It
+// lets resolveExtends merge a base definition's fields into a derived one
+// without the resolution pass needing to know whether the eventual task
+// is a scriptTask or a funcTask.
+type extendable interface {
+	// ExtendsIDs returns the task IDs named by this definition's
+	// `extends` field, in declaration order. Extends targets may be task
+	// IDs from child tasks files, using the same "child/build" notation
+	// as Dependencies.
+	ExtendsIDs() []string
+
+	// MergeFrom deep-copies base's script/func body, Watch, Dependencies,
+	// Triggers, and Type into the receiver wherever the receiver has not
+	// itself set an override, and appends to list fields the receiver
+	// marked for appending (extends_env, additional dependencies).
+	MergeFrom(base extendable)
+}
+
+// resolveExtends performs a topological pass over the `extends` edges
+// among defs (keyed by task ID), merging each base definition into its
+// derivatives before the loader constructs the concrete scriptTask or
+// funcTask values. The base task remains in defs, untouched aside from
+// being read, so it stays independently runnable.
+//
+// resolveExtends returns an error describing the first `extends` cycle
+// it finds, or the first `extends` target that names a task ID not
+// present among defs (for example a typo); Load returns either as a
+// load-time error. Tasks.Validate does not check `extends` edges.
+func resolveExtends(defs map[string]extendable) error {
+	const (
+		unvisited = 0
+		visiting  = 1
+		resolved  = 2
+	)
+	state := make(map[string]int, len(defs))
+
+	var visit func(id string, chain []string) error
+	visit = func(id string, chain []string) error {
+		switch state[id] {
+		case resolved:
+			return nil
+		case visiting:
+			return fmt.Errorf("extends cycle: %s -> %s", joinChain(chain), id)
+		}
+		def, ok := defs[id]
+		if !ok {
+			return fmt.Errorf("task %s: extends %q, which does not exist", chain[len(chain)-1], id)
+		}
+		state[id] = visiting
+		for _, baseID := range def.ExtendsIDs() {
+			if err := visit(baseID, append(chain, id)); err != nil {
+				return err
+			}
+			def.MergeFrom(defs[baseID])
+		}
+		state[id] = resolved
+		return nil
+	}
+
+	for id := range defs {
+		if err := visit(id, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func joinChain(chain []string) string {
+	out := ""
+	for i, id := range chain {
+		if i > 0 {
+			out += " -> "
+		}
+		out += id
+	}
+	return out
+}
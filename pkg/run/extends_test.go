@@ -0,0 +1,77 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveExtendsMergesBaseIntoDerived(t *testing.T) {
+	base := &tomlTask{ID: "base", Type: "short", Script: "echo base", Watch: []string{"a"}}
+	derived := &tomlTask{ID: "derived", Extends: stringList{"base"}}
+
+	defs := map[string]extendable{"base": base, "derived": derived}
+	require.NoError(t, resolveExtends(defs))
+
+	assert.Equal(t, "short", derived.Type)
+	assert.Equal(t, "echo base", derived.Script)
+	assert.Equal(t, []string{"a"}, derived.Watch)
+	assert.Equal(t, "short", base.Type, "the base task must remain independently runnable")
+}
+
+func TestResolveExtendsOverridesWin(t *testing.T) {
+	base := &tomlTask{ID: "base", Type: "short", Script: "echo base"}
+	derived := &tomlTask{ID: "derived", Type: "long", Extends: stringList{"base"}}
+
+	defs := map[string]extendable{"base": base, "derived": derived}
+	require.NoError(t, resolveExtends(defs))
+
+	assert.Equal(t, "long", derived.Type, "derived's own Type must not be clobbered by base's")
+	assert.Equal(t, "echo base", derived.Script, "Script was unset on derived, so it inherits base's")
+}
+
+func TestResolveExtendsAppendsDependencies(t *testing.T) {
+	base := &tomlTask{ID: "base", Dependencies: []string{"shared"}}
+	derived := &tomlTask{ID: "derived", Dependencies: []string{"extra"}, Extends: stringList{"base"}}
+
+	defs := map[string]extendable{"base": base, "derived": derived}
+	require.NoError(t, resolveExtends(defs))
+
+	assert.ElementsMatch(t, []string{"shared", "extra"}, derived.Dependencies)
+}
+
+func TestResolveExtendsDetectsCycle(t *testing.T) {
+	a := &tomlTask{ID: "a", Extends: stringList{"b"}}
+	b := &tomlTask{ID: "b", Extends: stringList{"a"}}
+
+	defs := map[string]extendable{"a": a, "b": b}
+	err := resolveExtends(defs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cycle")
+}
+
+func TestResolveExtendsRejectsUnknownTarget(t *testing.T) {
+	derived := &tomlTask{ID: "derived", Extends: stringList{"buidl"}}
+
+	defs := map[string]extendable{"derived": derived}
+	err := resolveExtends(defs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "buidl")
+	assert.Contains(t, err.Error(), "does not exist")
+}
+
+func TestLoadRejectsExtendsOfUnknownTarget(t *testing.T) {
+	_, err := Load("./testdata/extends-unknown-target")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not exist")
+}
+
+func TestResolveExtendsAcrossNestedFiles(t *testing.T) {
+	ts, err := Load("./testdata/extends")
+	require.NoError(t, err)
+
+	child := ts.Get("child/build")
+	require.NotNil(t, child)
+	assert.Equal(t, "echo building", child.(*scriptTask).script)
+}
@@ -0,0 +1,25 @@
+package run
+
+import (
+	"context"
+	"io"
+)
+
+// FuncTask is a Task implemented by an in-process Go function, for
+// library consumers who build their Tasks directly in Go rather than
+// loading them from a tasks.toml file.
+type FuncTask struct {
+	Meta TaskMetadata
+	Func func(ctx context.Context, stdout io.Writer) error
+}
+
+// Start calls f.Func. ctx carries the task's merged environment,
+// retrievable with [EnvFromContext].
+func (f FuncTask) Start(ctx context.Context, stdout io.Writer) error {
+	return f.Func(ctx, stdout)
+}
+
+// Metadata returns f.Meta.
+func (f FuncTask) Metadata() TaskMetadata {
+	return f.Meta
+}
@@ -0,0 +1,180 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// defaultRetryBackoff is used when a task sets Retries without an
+// explicit RetryBackoff.
+const defaultRetryBackoff = time.Second
+
+// starter is however RunTask attempts a single execution of a task: a
+// plain Task's Start, or, for a StagedTask, a closure running its
+// stages via runStages. Taking a starter rather than a Task lets
+// runWithRetries and runLongTask apply Timeout/Retries/Deadline/
+// RetryWindow bounding uniformly to either.
+type starter func(ctx context.Context, stdout io.Writer) error
+
+// boundedContext derives an attempt context from ctx bounded by
+// whichever of meta.Timeout and meta.Deadline are set, returning a
+// single CancelFunc that releases both derived contexts' resources
+// (calling only the outermost one would leave an inner WithTimeout's
+// timer running until it fires on its own).
+func boundedContext(ctx context.Context, meta TaskMetadata) (context.Context, context.CancelFunc) {
+	var cancels []context.CancelFunc
+	if meta.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, meta.Timeout)
+		cancels = append(cancels, cancel)
+	}
+	if !meta.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, meta.Deadline)
+		cancels = append(cancels, cancel)
+	}
+	return ctx, func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+}
+
+// runWithRetries invokes start, retrying a short task up to
+// meta.Retries times on error with RetryBackoff * 2^attempt backoff,
+// instead of Run's previous fixed one-second delay. Each attempt is
+// bounded by Timeout and Deadline via a derived context. It returns the
+// number of attempts made (0 if Deadline had already passed before the
+// first) alongside the final error, so the caller can record it via
+// recordStatus.
+func runWithRetries(ctx context.Context, meta TaskMetadata, start starter, stdout io.Writer) (int, error) {
+	backoff := meta.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= meta.Retries; attempt++ {
+		if !meta.Deadline.IsZero() && time.Now().After(meta.Deadline) {
+			return attempt, fmt.Errorf("task %s: deadline %s has passed", meta.ID, meta.Deadline)
+		}
+
+		attemptCtx, cancel := boundedContext(ctx, meta)
+		lastErr = start(attemptCtx, stdout)
+		cancel()
+		if lastErr == nil {
+			return attempt + 1, nil
+		}
+		if attempt < meta.Retries {
+			select {
+			case <-time.After(backoff * time.Duration(1<<uint(attempt))):
+			case <-ctx.Done():
+				return attempt + 1, ctx.Err()
+			}
+		}
+	}
+	return meta.Retries + 1, lastErr
+}
+
+// runLongTask runs start repeatedly, restarting it whenever it returns
+// for any reason, per TaskMetadata.Type "long" semantics ("we will keep
+// the task alive by restarting it if it exits"). Restarts are counted
+// within a rolling RetryWindow; once more than Retries restarts have
+// occurred inside the window, the task is recorded failed via
+// recordStatus and runLongTask returns rather than restarting again.
+// Each attempt is bounded by Timeout, and the loop stops, the same as
+// runWithRetries, once Deadline has passed or ctx is canceled.
+func runLongTask(ctx context.Context, meta TaskMetadata, start starter, stdout io.Writer) error {
+	var restarts []time.Time
+	var lastErr error
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !meta.Deadline.IsZero() && time.Now().After(meta.Deadline) {
+			return fmt.Errorf("task %s: deadline %s has passed", meta.ID, meta.Deadline)
+		}
+
+		attemptCtx, cancel := boundedContext(ctx, meta)
+		lastErr = start(attemptCtx, stdout)
+		cancel()
+
+		now := time.Now()
+		restarts = append(restarts, now)
+		if meta.RetryWindow > 0 {
+			restarts = pruneBefore(restarts, now.Add(-meta.RetryWindow))
+		}
+
+		if len(restarts) > meta.Retries+1 {
+			recordStatus(meta.ID, Status{Attempts: len(restarts), LastErr: lastErr, Failed: true})
+			return fmt.Errorf("task %s: exceeded %d restarts within %s", meta.ID, meta.Retries, meta.RetryWindow)
+		}
+		recordStatus(meta.ID, Status{Attempts: len(restarts), LastErr: lastErr, Failed: false})
+	}
+}
+
+// pruneBefore returns the suffix of ts (in place) with every time before
+// cutoff dropped, used to keep runLongTask's restart count scoped to the
+// rolling RetryWindow rather than growing unbounded over a long-running
+// process.
+func pruneBefore(ts []time.Time, cutoff time.Time) []time.Time {
+	out := ts[:0]
+	for _, t := range ts {
+		if t.After(cutoff) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Status describes a task's recent execution history, as tracked across
+// restart attempts within RetryWindow for a long task, or retries for a
+// short one.
+type Status struct {
+	Attempts int
+	LastErr  error
+	Failed   bool
+}
+
+var (
+	statusMu sync.Mutex
+	statuses = map[string]Status{}
+)
+
+// Status returns the status Run has recorded for the task with the given
+// ID. The zero Status is returned if nothing has been recorded yet.
+func (ts Tasks) Status(id string) Status {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+	return statuses[id]
+}
+
+// recordStatus is called by Run after each attempt of a task to update
+// the status later returned by Tasks.Status.
+func recordStatus(id string, s Status) {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+	statuses[id] = s
+}
+
+// validateTiming checks the Timeout/Retries/RetryBackoff/Deadline
+// fields of meta, returning a descriptive error for Tasks.Validate if
+// any are invalid.
+func validateTiming(meta TaskMetadata) error {
+	if meta.Retries < 0 {
+		return fmt.Errorf("task %s: Retries must be >= 0, got %d", meta.ID, meta.Retries)
+	}
+	if meta.Timeout < 0 {
+		return fmt.Errorf("task %s: Timeout must be > 0 if set, got %s", meta.ID, meta.Timeout)
+	}
+	if !meta.Deadline.IsZero() && meta.Deadline.Before(time.Now()) {
+		return fmt.Errorf("task %s: Deadline %s is already in the past", meta.ID, meta.Deadline)
+	}
+	if meta.Type == "long" && meta.Retries > 0 && meta.RetryWindow <= 0 {
+		return fmt.Errorf("task %s: long tasks must set RetryWindow when Retries is set", meta.ID)
+	}
+	return nil
+}
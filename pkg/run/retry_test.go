@@ -0,0 +1,119 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyTask fails its first failUntil calls to Start, then succeeds.
+type flakyTask struct {
+	meta      TaskMetadata
+	calls     *int
+	failUntil int
+}
+
+func (f flakyTask) Start(ctx context.Context, stdout io.Writer) error {
+	*f.calls++
+	if *f.calls <= f.failUntil {
+		return errors.New("not yet")
+	}
+	return nil
+}
+
+func (f flakyTask) Metadata() TaskMetadata { return f.meta }
+
+func TestRunWithRetriesSucceedsAfterFailures(t *testing.T) {
+	calls := 0
+	task := flakyTask{
+		meta:      TaskMetadata{ID: "build", Type: "short", Retries: 2, RetryBackoff: time.Millisecond},
+		calls:     &calls,
+		failUntil: 2,
+	}
+
+	attempts, err := runWithRetries(context.Background(), task.meta, task.Start, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRunWithRetriesExhausted(t *testing.T) {
+	calls := 0
+	task := flakyTask{
+		meta:      TaskMetadata{ID: "build", Type: "short", Retries: 1, RetryBackoff: time.Millisecond},
+		calls:     &calls,
+		failUntil: 999,
+	}
+
+	attempts, err := runWithRetries(context.Background(), task.meta, task.Start, io.Discard)
+	assert.Error(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, 2, calls)
+}
+
+func TestRunWithRetriesStopsAtPastDeadline(t *testing.T) {
+	calls := 0
+	task := flakyTask{
+		meta:  TaskMetadata{ID: "build", Type: "short", Retries: 3, Deadline: time.Now().Add(-time.Hour)},
+		calls: &calls,
+	}
+
+	attempts, err := runWithRetries(context.Background(), task.meta, task.Start, io.Discard)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "deadline")
+	assert.Equal(t, 0, attempts)
+	assert.Equal(t, 0, calls)
+}
+
+func TestRunLongTaskFailsAfterExceedingRetryWindow(t *testing.T) {
+	calls := 0
+	task := flakyTask{
+		meta:  TaskMetadata{ID: "server", Type: "long", Retries: 2, RetryWindow: time.Minute},
+		calls: &calls,
+	}
+
+	err := runLongTask(context.Background(), task.meta, task.Start, io.Discard)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeded")
+	assert.Equal(t, 4, calls, "should run once, then restart Retries more times, then one more that trips the window")
+
+	var noTasks Tasks
+	status := noTasks.Status("server")
+	assert.True(t, status.Failed)
+	assert.Equal(t, 4, status.Attempts)
+}
+
+func TestRunLongTaskStopsAtPastDeadline(t *testing.T) {
+	calls := 0
+	task := flakyTask{
+		meta:  TaskMetadata{ID: "server", Type: "long", Deadline: time.Now().Add(-time.Hour)},
+		calls: &calls,
+	}
+
+	err := runLongTask(context.Background(), task.meta, task.Start, io.Discard)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "deadline")
+	assert.Equal(t, 0, calls, "a long task whose deadline has already passed must not be (re)started at all")
+}
+
+func TestRunTaskRecordsStatusForShortTask(t *testing.T) {
+	chdirTemp(t)
+	calls := 0
+	task := flakyTask{
+		meta:      TaskMetadata{ID: "status-short", Type: "short", Retries: 2, RetryBackoff: time.Millisecond},
+		calls:     &calls,
+		failUntil: 1,
+	}
+	ts := NewTasks([]Task{task})
+
+	require.NoError(t, RunTask(context.Background(), ts, "status-short", io.Discard))
+
+	status := ts.Status("status-short")
+	assert.False(t, status.Failed)
+	assert.Equal(t, 2, status.Attempts)
+}
@@ -0,0 +1,74 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// RunTask runs the single task identified by id from ts to completion.
+//
+// If the task is short and its last recorded execution succeeded with
+// the same cache fingerprint (see [TaskMetadata.Inputs]), Start is
+// skipped and a "(cached)" line is printed to stdout instead. Otherwise
+// RunTask picks how to attempt the task once - Start, or, if it also
+// implements [StagedTask], Plan is preferred and its stages are run via
+// runStages - and then runs that attempt via runWithRetries (honoring
+// Retries, RetryBackoff, Timeout, and Deadline) or, for a long task,
+// runLongTask (which restarts it whenever it exits until RetryWindow
+// has seen more restarts than Retries allows). A StagedTask is bounded
+// by these same Retries/Timeout/Deadline rules as an ordinary task, not
+// exempted from them. Either way the outcome is recorded both for
+// future fingerprint comparisons and, via recordStatus, for
+// [Tasks.Status].
+//
+// RunTask is the minimal single-task entry point the cache, env,
+// artifact, retry, and staged-task machinery in this package hook into;
+// the dependency/trigger/watch graph scheduler that runs many tasks
+// together lives in the `run` command.
+func RunTask(ctx context.Context, ts Tasks, id string, stdout io.Writer) error {
+	t := ts.Get(id)
+	if t == nil {
+		return fmt.Errorf("no such task %q", id)
+	}
+
+	if skip, err := shouldSkip(t); err != nil {
+		return err
+	} else if skip {
+		fmt.Fprintf(stdout, "%s (cached)\n", t.Metadata().ID)
+		return nil
+	}
+
+	meta := t.Metadata()
+	if len(meta.Artifacts) > 0 {
+		dir := "."
+		if dt, ok := t.(dirTask); ok {
+			dir = dt.Dir()
+		}
+		if err := resolveArtifacts(ctx, dir, meta.Artifacts); err != nil {
+			return err
+		}
+	}
+
+	ctx = contextWithEnv(ctx, meta.Env)
+
+	start := starter(t.Start)
+	if st, ok := t.(StagedTask); ok {
+		start = func(ctx context.Context, stdout io.Writer) error {
+			return runStages(ctx, st, stdout, 0, nil)
+		}
+	}
+
+	var err error
+	if meta.Type == "long" {
+		err = runLongTask(ctx, meta, start, stdout)
+	} else {
+		var attempts int
+		attempts, err = runWithRetries(ctx, meta, start, stdout)
+		recordStatus(meta.ID, Status{Attempts: attempts, LastErr: err, Failed: err != nil})
+	}
+	if recErr := recordResult(t, err); err == nil {
+		err = recErr
+	}
+	return err
+}
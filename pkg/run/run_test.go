@@ -0,0 +1,71 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTask records how many times Start has actually been invoked,
+// so tests can assert that a cached run skips it.
+type countingTask struct {
+	meta  TaskMetadata
+	calls *int
+}
+
+func (c countingTask) Start(ctx context.Context, stdout io.Writer) error {
+	*c.calls++
+	return nil
+}
+
+func (c countingTask) Metadata() TaskMetadata { return c.meta }
+
+func TestRunTaskSkipsWhenCached(t *testing.T) {
+	dir := chdirTemp(t)
+	file := filepath.Join(dir, "in.txt")
+	require.NoError(t, os.WriteFile(file, []byte("v1"), 0o644))
+
+	calls := 0
+	task := countingTask{
+		meta:  TaskMetadata{ID: "build", Type: "short", Inputs: []string{file}},
+		calls: &calls,
+	}
+	ts := NewTasks([]Task{task})
+
+	var out bytes.Buffer
+	require.NoError(t, RunTask(context.Background(), ts, "build", &out))
+	assert.Equal(t, 1, calls)
+	assert.NotContains(t, out.String(), "(cached)")
+
+	out.Reset()
+	require.NoError(t, RunTask(context.Background(), ts, "build", &out))
+	assert.Equal(t, 1, calls, "second run should be skipped from cache")
+	assert.Contains(t, out.String(), "(cached)")
+}
+
+func TestRunTaskUnknownID(t *testing.T) {
+	ts := NewTasks(nil)
+	err := RunTask(context.Background(), ts, "missing", io.Discard)
+	assert.Error(t, err)
+}
+
+func TestRunTaskPlumbsEnvToFuncTask(t *testing.T) {
+	var seen map[string]string
+	task := FuncTask{
+		Meta: TaskMetadata{ID: "build", Type: "short", Env: map[string]string{"FOO": "bar"}},
+		Func: func(ctx context.Context, stdout io.Writer) error {
+			seen = EnvFromContext(ctx)
+			return nil
+		},
+	}
+	ts := NewTasks([]Task{task})
+
+	require.NoError(t, RunTask(context.Background(), ts, "build", io.Discard))
+	assert.Equal(t, map[string]string{"FOO": "bar"}, seen)
+}
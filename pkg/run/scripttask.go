@@ -0,0 +1,51 @@
+package run
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// scriptTask runs a shell script in a directory. It's the concrete Task
+// type the tasks.toml loader produces for a task declaring a `script`.
+type scriptTask struct {
+	meta   TaskMetadata
+	dir    string
+	script string
+}
+
+// Metadata returns s's TaskMetadata.
+func (s *scriptTask) Metadata() TaskMetadata {
+	return s.meta
+}
+
+// Dir implements dirTask, so Run resolves s's Artifacts into s.dir
+// rather than the process's working directory.
+func (s *scriptTask) Dir() string {
+	return s.dir
+}
+
+// FingerprintBytes includes the script body in s's cache fingerprint, so
+// editing the command invalidates the cache even though no watched file
+// changed.
+func (s *scriptTask) FingerprintBytes() []byte {
+	return []byte(s.script)
+}
+
+// Start runs s.script with "sh -c" in s.dir. The task's merged
+// environment (s.meta.Env layered with whatever ctx carries via
+// [EnvFromContext]) is appended after the process's environment, so its
+// entries take precedence.
+func (s *scriptTask) Start(ctx context.Context, stdout io.Writer) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.script)
+	cmd.Dir = s.dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stdout
+
+	cmd.Env = os.Environ()
+	for k, v := range mergeEnv(s.meta.Env, EnvFromContext(ctx)) {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	return cmd.Run()
+}
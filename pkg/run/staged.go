@@ -0,0 +1,145 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// stagePollInterval is how often runStage re-checks a Stage's
+// StageFinished predicate while waiting for asynchronous work kicked off
+// by its Subtasks to complete.
+const stagePollInterval = 100 * time.Millisecond
+
+// StagedTask is implemented by tasks whose work is better modeled as an
+// ordered sequence of phases than as a single Start call, or as N
+// hand-written interdependent tasks in TOML. For example, a single
+// StagedTask can model "scan -> split -> process shards -> merge",
+// generating one Stage per phase and, for "process shards", one subtask
+// per shard discovered while the scan stage ran.
+//
+// Run prefers Plan over Start when a Task also implements StagedTask.
+type StagedTask interface {
+	Metadata() TaskMetadata
+
+	// Plan returns the task's stages, in the order they should run. Plan
+	// may be called again on a later execution of the same task (for
+	// example after a Watch event), so it should recompute stages from
+	// current state rather than assuming it runs once.
+	Plan(ctx context.Context) ([]Stage, error)
+}
+
+// Stage is one phase of a StagedTask: a named set of subtasks that can
+// run concurrently, followed by a check for whether the stage is
+// actually done.
+type Stage struct {
+	// Name identifies the stage, for example in the TUI's task list.
+	Name string
+
+	// Subtasks are started concurrently, up to runStages' parallelism
+	// argument, when this stage begins. RunTask passes no limit, so all
+	// of a stage's Subtasks run at once.
+	Subtasks []Task
+
+	// StageFinished, if set, is polled by Run once all Subtasks have
+	// returned from Start, and the stage advances only when it reports
+	// true. Without it, the stage advances as soon as all Subtasks have
+	// returned from Start. This supports subtasks that
+	// signal completion asynchronously (for example, a subtask that
+	// kicks off external work and returns immediately).
+	StageFinished func(ctx context.Context) (bool, error)
+}
+
+// runStages executes a StagedTask's stages in order, running each
+// stage's subtasks concurrently up to parallelism (or unbounded if
+// parallelism <= 0), and only advancing once the stage's subtasks have
+// completed and StageFinished (or the default predicate) reports done.
+// progress, if non-nil, is called after every subtask completion so a
+// caller such as the TUI can report per-stage progress.
+func runStages(ctx context.Context, st StagedTask, stdout io.Writer, parallelism int, progress func(stageIdx int, stage Stage, done, total int)) error {
+	stages, err := st.Plan(ctx)
+	if err != nil {
+		return fmt.Errorf("task %s: plan: %w", st.Metadata().ID, err)
+	}
+
+	for i, stage := range stages {
+		err := runStage(ctx, stage, stdout, parallelism, func(done, total int) {
+			if progress != nil {
+				progress(i, stage, done, total)
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("task %s: stage %q: %w", st.Metadata().ID, stage.Name, err)
+		}
+	}
+	return nil
+}
+
+func runStage(ctx context.Context, stage Stage, stdout io.Writer, parallelism int, progress func(done, total int)) error {
+	if parallelism <= 0 {
+		parallelism = len(stage.Subtasks)
+	}
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+
+	sem := make(chan struct{}, parallelism)
+	errs := make(chan error, len(stage.Subtasks))
+	var wg sync.WaitGroup
+	var done int32
+	total := len(stage.Subtasks)
+
+	for _, sub := range stage.Subtasks {
+		sub := sub
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			err := sub.Start(ctx, stdout)
+			if progress != nil {
+				progress(int(atomic.AddInt32(&done, 1)), total)
+			}
+			if err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	if stage.StageFinished == nil {
+		return nil
+	}
+	return pollUntilFinished(ctx, stage.StageFinished)
+}
+
+// pollUntilFinished calls finished every stagePollInterval until it
+// reports done, returns an error, or ctx is canceled. This is what lets
+// a Stage's Subtasks kick off external work and return from Start
+// immediately, while the stage itself keeps waiting for that work to
+// actually finish rather than failing the instant Subtasks return.
+func pollUntilFinished(ctx context.Context, finished func(ctx context.Context) (bool, error)) error {
+	for {
+		ok, err := finished(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(stagePollInterval):
+		}
+	}
+}
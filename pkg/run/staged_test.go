@@ -0,0 +1,202 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// planTask is a minimal StagedTask backed by a fixed, pre-built []Stage.
+type planTask struct {
+	meta   TaskMetadata
+	stages []Stage
+}
+
+func (p planTask) Metadata() TaskMetadata { return p.meta }
+
+// Start should never be called on a planTask: Run prefers Plan whenever
+// a Task also implements StagedTask.
+func (p planTask) Start(ctx context.Context, stdout io.Writer) error {
+	panic("planTask.Start called despite implementing StagedTask")
+}
+
+func (p planTask) Plan(ctx context.Context) ([]Stage, error) {
+	return p.stages, nil
+}
+
+// funcSubtask adapts a plain func into a Task for use as a Stage Subtask.
+type funcSubtask struct {
+	fn func(ctx context.Context, stdout io.Writer) error
+}
+
+func (f funcSubtask) Start(ctx context.Context, stdout io.Writer) error { return f.fn(ctx, stdout) }
+func (f funcSubtask) Metadata() TaskMetadata                            { return TaskMetadata{ID: "sub", Type: "short"} }
+
+func TestRunStagePollsStageFinishedUntilTrue(t *testing.T) {
+	var checks int32
+	stage := Stage{
+		Name: "wait",
+		Subtasks: []Task{
+			funcSubtask{fn: func(ctx context.Context, stdout io.Writer) error { return nil }},
+		},
+		StageFinished: func(ctx context.Context) (bool, error) {
+			return atomic.AddInt32(&checks, 1) >= 3, nil
+		},
+	}
+
+	err := runStage(context.Background(), stage, io.Discard, 0, nil)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&checks), int32(3))
+}
+
+func TestRunStagePropagatesStageFinishedError(t *testing.T) {
+	stage := Stage{
+		Subtasks: []Task{funcSubtask{fn: func(ctx context.Context, stdout io.Writer) error { return nil }}},
+		StageFinished: func(ctx context.Context) (bool, error) {
+			return false, errors.New("external check failed")
+		},
+	}
+
+	err := runStage(context.Background(), stage, io.Discard, 0, nil)
+	assert.ErrorContains(t, err, "external check failed")
+}
+
+func TestRunStageCancelsPollOnContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stage := Stage{
+		Subtasks:      []Task{funcSubtask{fn: func(ctx context.Context, stdout io.Writer) error { return nil }}},
+		StageFinished: func(ctx context.Context) (bool, error) { return false, nil },
+	}
+
+	err := runStage(ctx, stage, io.Discard, 0, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRunStageFansOutAndReportsSubtaskError(t *testing.T) {
+	stage := Stage{
+		Subtasks: []Task{
+			funcSubtask{fn: func(ctx context.Context, stdout io.Writer) error { return nil }},
+			funcSubtask{fn: func(ctx context.Context, stdout io.Writer) error { return errors.New("boom") }},
+		},
+	}
+
+	err := runStage(context.Background(), stage, io.Discard, 0, nil)
+	assert.ErrorContains(t, err, "boom")
+}
+
+func TestRunStageRespectsParallelismCap(t *testing.T) {
+	const parallelism = 2
+	var current, maxSeen int32
+	var mu sync.Mutex
+
+	subtasks := make([]Task, 8)
+	for i := range subtasks {
+		subtasks[i] = funcSubtask{fn: func(ctx context.Context, stdout io.Writer) error {
+			n := atomic.AddInt32(&current, 1)
+			mu.Lock()
+			if n > maxSeen {
+				maxSeen = n
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&current, -1)
+			return nil
+		}}
+	}
+
+	err := runStage(context.Background(), Stage{Subtasks: subtasks}, io.Discard, parallelism, nil)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, maxSeen, int32(parallelism))
+}
+
+func TestRunStageReportsProgress(t *testing.T) {
+	subtasks := make([]Task, 3)
+	for i := range subtasks {
+		subtasks[i] = funcSubtask{fn: func(ctx context.Context, stdout io.Writer) error { return nil }}
+	}
+
+	var mu sync.Mutex
+	var doneCalls []int
+	err := runStage(context.Background(), Stage{Subtasks: subtasks}, io.Discard, 0, func(done, total int) {
+		mu.Lock()
+		doneCalls = append(doneCalls, done)
+		mu.Unlock()
+		assert.Equal(t, 3, total)
+	})
+	require.NoError(t, err)
+	assert.Len(t, doneCalls, 3)
+}
+
+func TestRunStagesRunsEveryStageInOrder(t *testing.T) {
+	var order []string
+	var mu sync.Mutex
+	record := func(name string) func(ctx context.Context, stdout io.Writer) error {
+		return func(ctx context.Context, stdout io.Writer) error {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	task := planTask{
+		meta: TaskMetadata{ID: "pipeline", Type: "short"},
+		stages: []Stage{
+			{Name: "scan", Subtasks: []Task{funcSubtask{fn: record("scan")}}},
+			{Name: "process", Subtasks: []Task{funcSubtask{fn: record("process")}}},
+		},
+	}
+
+	require.NoError(t, runStages(context.Background(), task, io.Discard, 0, nil))
+	assert.Equal(t, []string{"scan", "process"}, order)
+}
+
+func TestRunTaskPrefersPlanOverStartForStagedTask(t *testing.T) {
+	var ran int32
+	task := planTask{
+		meta: TaskMetadata{ID: "pipeline", Type: "short"},
+		stages: []Stage{
+			{Subtasks: []Task{funcSubtask{fn: func(ctx context.Context, stdout io.Writer) error {
+				atomic.AddInt32(&ran, 1)
+				return nil
+			}}}},
+		},
+	}
+	ts := NewTasks([]Task{task})
+
+	require.NoError(t, RunTask(context.Background(), ts, "pipeline", io.Discard))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ran))
+}
+
+func TestRunTaskRetriesStagedTaskAndRecordsStatus(t *testing.T) {
+	chdirTemp(t)
+	var planCalls int32
+	task := planTask{
+		meta: TaskMetadata{ID: "pipeline", Type: "short", Retries: 2, RetryBackoff: time.Millisecond},
+		stages: []Stage{
+			{Subtasks: []Task{funcSubtask{fn: func(ctx context.Context, stdout io.Writer) error {
+				if atomic.AddInt32(&planCalls, 1) <= 2 {
+					return errors.New("not yet")
+				}
+				return nil
+			}}}},
+		},
+	}
+	ts := NewTasks([]Task{task})
+
+	require.NoError(t, RunTask(context.Background(), ts, "pipeline", io.Discard))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&planCalls), "a failing StagedTask attempt must be retried like any other task")
+
+	status := ts.Status("pipeline")
+	assert.False(t, status.Failed)
+	assert.Equal(t, 3, status.Attempts)
+}
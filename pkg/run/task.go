@@ -3,6 +3,7 @@ package run
 import (
 	"context"
 	"io"
+	"time"
 )
 
 // Tasks is an opaque data structure representing an immutable, ordered
@@ -33,6 +34,15 @@ func NewTasks(tasks []Task) Tasks {
 // [ScriptTask] and [FuncTask] can be used to create Tasks.
 //
 // A Task must be safe to access concurrently from multiple goroutines.
+//
+// A Task that wants its script body or other internal state to
+// participate in cache fingerprinting (see [TaskMetadata.Inputs]) should
+// additionally implement [FingerprintContributor].
+//
+// A task whose work is better modeled as an ordered sequence of phases,
+// each fanning out into concurrent subtasks (for example "scan -> split
+// -> process shards -> merge"), should additionally implement
+// [StagedTask]; RunTask then runs its stages in place of calling Start.
 type Task interface {
 	Start(ctx context.Context, stdout io.Writer) error
 	Metadata() TaskMetadata
@@ -118,6 +128,75 @@ type TaskMetadata struct {
 	//  - `"./src/website/**/*.js"` watches for changes
 	//    to javascript files within src/website.
 	Watch []string
+
+	// Inputs lists glob patterns, just like Watch, whose resolved files
+	// are hashed to build the task's cache fingerprint. When a short
+	// task's Inputs have not changed in content since its last
+	// successful Start, Run skips re-executing it and prints a
+	// "(cached)" line instead. A task with neither Inputs nor Artifacts
+	// is never cached.
+	//
+	// The on-disk fingerprint log lives under "./.run/cache/<id>/" (with
+	// each "/" in the ID replaced by "_") and can be cleared with
+	// [Tasks.ClearCache].
+	Inputs []string
+
+	// FingerprintEnv names environment variables whose observed values
+	// should be folded into the cache fingerprint alongside Inputs, so
+	// that a task whose behavior depends on an env var is rerun when
+	// that var changes even though no watched file did.
+	FingerprintEnv []string
+
+	// Env holds environment variables to set for this task's execution,
+	// merged over the tasks file's top-level [env] table (a task's own
+	// entries win on conflict). For a scriptTask, Env is appended to
+	// exec.Cmd.Env after the process's own environment, so its entries
+	// take precedence; for a FuncTask, it is retrieved with
+	// [EnvFromContext]. ${VAR} references in Watch, Dependencies,
+	// Triggers, and script bodies are interpolated against this merged
+	// environment at load time; a reference to an undefined variable
+	// makes Load return an error. [Tasks.Validate] does not check for
+	// undefined variables.
+	Env map[string]string
+
+	// Artifacts lists remote files or archives that Run must fetch and
+	// place into the task's directory before Start is called. See
+	// [Artifact] for the fields each entry supports.
+	Artifacts []Artifact
+
+	// Timeout, if nonzero, bounds a single invocation of Start: Run
+	// derives a context.WithTimeout from it before calling Start, and
+	// treats a context deadline exceeded error the same as any other
+	// failure for retry purposes.
+	Timeout time.Duration
+
+	// Deadline, if non-zero, is an absolute wall-clock cutoff after
+	// which Run will not start or retry this task, regardless of
+	// Retries remaining. It is meant for CI-style grading runs with a
+	// hard time budget. It is a [Tasks.Validate] error for Deadline to
+	// already be in the past at load time.
+	Deadline time.Time
+
+	// Retries is the number of additional attempts Run makes after a
+	// short task's Start returns an error, replacing the runner's
+	// default fixed one-second retry delay with RetryBackoff. For a long
+	// task, Retries bounds the number of restart attempts within
+	// RetryWindow before the task is reported failed through
+	// [Tasks.Status]. Retries must be >= 0.
+	Retries int
+
+	// RetryBackoff is the base delay before each retry of a short task;
+	// the first retry waits RetryBackoff, and each later retry waits
+	// twice as long as the one before it. It defaults to one second
+	// if Retries is set without a RetryBackoff.
+	RetryBackoff time.Duration
+
+	// RetryWindow bounds, for a long task, the period over which Retries
+	// restart attempts are counted; once more than Retries restarts have
+	// occurred within a rolling RetryWindow, the task is marked failed rather
+	// than restarted again. It is a [Tasks.Validate] error for a long
+	// task to set Retries without a RetryWindow.
+	RetryWindow time.Duration
 }
 
 // IDs returns the task IDs in their canonical order.
@@ -0,0 +1,298 @@
+package run
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// tasksFileName is the filename Load looks for in dir and in every
+// descendant directory.
+const tasksFileName = "tasks.toml"
+
+// stringList decodes a TOML value that may be written as either a single
+// string or an array of strings, used for `extends`.
+type stringList []string
+
+// tomlDuration decodes a TOML string written in [time.ParseDuration]
+// syntax (for example "5s", "2m30s") into a time.Duration, used for
+// `timeout`, `retry_backoff`, and `retry_window`.
+type tomlDuration time.Duration
+
+func (d *tomlDuration) UnmarshalTOML(data interface{}) error {
+	s, ok := data.(string)
+	if !ok {
+		return fmt.Errorf("expected a duration string, got %T", data)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = tomlDuration(parsed)
+	return nil
+}
+
+func (s *stringList) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case string:
+		*s = stringList{v}
+	case []interface{}:
+		out := make(stringList, len(v))
+		for i, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("extends: expected a string, got %T", item)
+			}
+			out[i] = str
+		}
+		*s = out
+	default:
+		return fmt.Errorf("extends: expected a string or a list of strings, got %T", data)
+	}
+	return nil
+}
+
+// tomlArtifact is the TOML shape of an [[task.artifacts]] table.
+type tomlArtifact struct {
+	Source   string `toml:"source"`
+	Dest     string `toml:"dest"`
+	Checksum string `toml:"checksum"`
+	Mode     string `toml:"mode"`
+}
+
+// tomlTask is the raw shape of a `[[task]]` table in a tasks.toml file,
+// before extends resolution and construction into a concrete Task.
+type tomlTask struct {
+	ID             string            `toml:"id"`
+	Description    string            `toml:"description"`
+	Type           string            `toml:"type"`
+	Dependencies   []string          `toml:"dependencies"`
+	Triggers       []string          `toml:"triggers"`
+	Watch          []string          `toml:"watch"`
+	Inputs         []string          `toml:"inputs"`
+	FingerprintEnv []string          `toml:"fingerprint_env"`
+	Env            map[string]string `toml:"env"`
+	Artifacts      []tomlArtifact    `toml:"artifacts"`
+	Script         string            `toml:"script"`
+	Extends        stringList        `toml:"extends"`
+	ExtendsEnv     []string          `toml:"extends_env"`
+	Timeout        tomlDuration      `toml:"timeout"`
+	Deadline       time.Time         `toml:"deadline"`
+	Retries        int               `toml:"retries"`
+	RetryBackoff   tomlDuration      `toml:"retry_backoff"`
+	RetryWindow    tomlDuration      `toml:"retry_window"`
+
+	// dir is the directory the owning tasks.toml lives in; it isn't a
+	// TOML field, it's set by loadDir.
+	dir string
+}
+
+// ExtendsIDs implements extendable.
+func (t *tomlTask) ExtendsIDs() []string {
+	return []string(t.Extends)
+}
+
+// MergeFrom implements extendable: fields t has not itself set inherit
+// base's value; Dependencies and Triggers declared alongside extends are
+// appended to base's rather than replacing them, and each variable named
+// in ExtendsEnv that t's Env lacks is copied from base's Env.
+func (t *tomlTask) MergeFrom(baseIface extendable) {
+	base, ok := baseIface.(*tomlTask)
+	if !ok {
+		return
+	}
+	if t.Type == "" {
+		t.Type = base.Type
+	}
+	if t.Script == "" {
+		t.Script = base.Script
+	}
+	if len(t.Watch) == 0 {
+		t.Watch = append([]string(nil), base.Watch...)
+	}
+	t.Dependencies = append(append([]string(nil), base.Dependencies...), t.Dependencies...)
+	t.Triggers = append(append([]string(nil), base.Triggers...), t.Triggers...)
+	if len(t.Inputs) == 0 {
+		t.Inputs = append([]string(nil), base.Inputs...)
+	}
+	if len(t.FingerprintEnv) == 0 {
+		t.FingerprintEnv = append([]string(nil), base.FingerprintEnv...)
+	}
+	if len(t.Env) == 0 {
+		t.Env = mergeEnv(base.Env, nil)
+	}
+	if len(t.Artifacts) == 0 {
+		t.Artifacts = append([]tomlArtifact(nil), base.Artifacts...)
+	}
+	for _, name := range t.ExtendsEnv {
+		if _, ok := t.Env[name]; !ok {
+			if v, ok := base.Env[name]; ok {
+				if t.Env == nil {
+					t.Env = map[string]string{}
+				}
+				t.Env[name] = v
+			}
+		}
+	}
+}
+
+// tomlFile is the shape of a tasks.toml file itself.
+type tomlFile struct {
+	Env   map[string]string `toml:"env"`
+	Tasks []tomlTask         `toml:"task"`
+}
+
+// Load reads the tasks.toml file in dir, and in every descendant
+// directory that contains one, into a single Tasks. A task declared in a
+// nested tasks.toml is identified by its path relative to dir joined
+// with its own id with "/", e.g. "child/build".
+func Load(dir string) (Tasks, error) {
+	defs := map[string]*tomlTask{}
+	if err := loadDir(dir, dir, nil, defs); err != nil {
+		return Tasks{}, err
+	}
+
+	toExtend := make(map[string]extendable, len(defs))
+	for id, def := range defs {
+		toExtend[id] = def
+	}
+	if err := resolveExtends(toExtend); err != nil {
+		return Tasks{}, err
+	}
+
+	ids := make([]string, 0, len(defs))
+	for id := range defs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	tasks := make([]Task, 0, len(defs))
+	for _, id := range ids {
+		t, err := buildTask(id, defs[id])
+		if err != nil {
+			return Tasks{}, err
+		}
+		tasks = append(tasks, t)
+	}
+
+	ts := NewTasks(tasks)
+	if err := ts.Validate(); err != nil {
+		return Tasks{}, err
+	}
+	return ts, nil
+}
+
+// loadDir reads the tasks.toml file in dir, if any, and recurses into
+// dir's subdirectories. parentEnv is the [env] table accumulated from
+// root down to dir's parent; dir's own [env] table is merged on top of
+// it (dir's entries win on conflict) both for dir's own tasks and for
+// whatever's passed down to its subdirectories, so a child tasks.toml
+// inherits and can override its ancestors' env.
+func loadDir(root, dir string, parentEnv map[string]string, defs map[string]*tomlTask) error {
+	path := filepath.Join(dir, tasksFileName)
+	data, err := os.ReadFile(path)
+	dirEnv := parentEnv
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		var file tomlFile
+		if _, err := toml.Decode(string(data), &file); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return err
+		}
+
+		dirEnv = mergeEnv(parentEnv, file.Env)
+
+		for i := range file.Tasks {
+			def := &file.Tasks[i]
+			def.dir = filepath.Clean(dir)
+			def.Env = mergeEnv(dirEnv, def.Env)
+
+			id := def.ID
+			if rel != "." {
+				id = rel + "/" + id
+			}
+			def.ID = id
+
+			if _, exists := defs[id]; exists {
+				return fmt.Errorf("%s: duplicate task id %q", path, id)
+			}
+			defs[id] = def
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if err := loadDir(root, filepath.Join(dir, e.Name()), dirEnv, defs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildTask constructs the concrete scriptTask for def, interpolating
+// ${VAR} references in Watch, Dependencies, Triggers, and the script
+// body against def.Env. It returns an error naming the first undefined
+// variable it encounters.
+func buildTask(id string, def *tomlTask) (Task, error) {
+	watch, err := interpolateAll(def.Watch, def.Env)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", id, err)
+	}
+	deps, err := interpolateAll(def.Dependencies, def.Env)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", id, err)
+	}
+	triggers, err := interpolateAll(def.Triggers, def.Env)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", id, err)
+	}
+	script, err := interpolate(def.Script, def.Env)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", id, err)
+	}
+
+	var artifacts []Artifact
+	if len(def.Artifacts) > 0 {
+		artifacts = make([]Artifact, len(def.Artifacts))
+		for i, a := range def.Artifacts {
+			artifacts[i] = Artifact{Source: a.Source, Dest: a.Dest, Checksum: a.Checksum, Mode: a.Mode}
+		}
+	}
+
+	meta := TaskMetadata{
+		ID:             id,
+		Description:    def.Description,
+		Type:           def.Type,
+		Dependencies:   deps,
+		Triggers:       triggers,
+		Watch:          watch,
+		Inputs:         def.Inputs,
+		FingerprintEnv: def.FingerprintEnv,
+		Env:            def.Env,
+		Artifacts:      artifacts,
+		Timeout:        time.Duration(def.Timeout),
+		Deadline:       def.Deadline,
+		Retries:        def.Retries,
+		RetryBackoff:   time.Duration(def.RetryBackoff),
+		RetryWindow:    time.Duration(def.RetryWindow),
+	}
+
+	return &scriptTask{meta: meta, dir: def.dir, script: script}, nil
+}
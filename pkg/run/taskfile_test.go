@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/sergi/go-diff/diffmatchpatch"
 	"github.com/stretchr/testify/assert"
@@ -18,11 +19,11 @@ func TestTaskfileNestingWithDir(t *testing.T) {
 	testNesting(t, ts)
 
 	assert.Equal(t, "testdata/very-nested",
-		ts["test"].(*scriptTask).dir)
+		ts.Get("test").(*scriptTask).dir)
 	assert.Equal(t, "testdata/very-nested/child",
-		ts["child/test"].(*scriptTask).dir)
+		ts.Get("child/test").(*scriptTask).dir)
 	assert.Equal(t, "testdata/very-nested/child/grandchild",
-		ts["child/grandchild/test"].(*scriptTask).dir)
+		ts.Get("child/grandchild/test").(*scriptTask).dir)
 }
 
 func TestTaskfileNestingWithDot(t *testing.T) {
@@ -35,17 +36,17 @@ func TestTaskfileNestingWithDot(t *testing.T) {
 	testNesting(t, ts)
 
 	assert.Equal(t, ".",
-		ts["test"].(*scriptTask).dir)
+		ts.Get("test").(*scriptTask).dir)
 	assert.Equal(t, "child",
-		ts["child/test"].(*scriptTask).dir)
+		ts.Get("child/test").(*scriptTask).dir)
 	assert.Equal(t, "child/grandchild",
-		ts["child/grandchild/test"].(*scriptTask).dir)
+		ts.Get("child/grandchild/test").(*scriptTask).dir)
 }
 
 func testNesting(t *testing.T, ts Tasks) {
 	metas := map[string]TaskMetadata{}
-	for id, t := range ts {
-		metas[id] = t.Metadata()
+	for _, id := range ts.IDs() {
+		metas[id] = ts.Get(id).Metadata()
 	}
 
 	assert.Equal(t, map[string]TaskMetadata{
@@ -69,13 +70,38 @@ func testNesting(t *testing.T, ts Tasks) {
 	}, metas)
 }
 
+func TestLoadEnvInheritsFromParentAndCanOverride(t *testing.T) {
+	ts, err := Load("./testdata/env-inherit")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"FOO": "parent-foo", "BAR": "parent-bar"},
+		ts.Get("build").Metadata().Env)
+	assert.Equal(t, map[string]string{"FOO": "parent-foo", "BAR": "child-bar"},
+		ts.Get("child/build").Metadata().Env)
+}
+
+func TestLoadWiresTimingFields(t *testing.T) {
+	ts, err := Load("./testdata/timing")
+	require.NoError(t, err)
+
+	build := ts.Get("build").Metadata()
+	assert.Equal(t, 5*time.Second, build.Timeout)
+	assert.Equal(t, 3, build.Retries)
+	assert.Equal(t, 250*time.Millisecond, build.RetryBackoff)
+	assert.Equal(t, 2030, build.Deadline.Year())
+
+	server := ts.Get("server").Metadata()
+	assert.Equal(t, 2, server.Retries)
+	assert.Equal(t, time.Minute, server.RetryWindow)
+}
+
 func TestDescriptions(t *testing.T) {
 	ts, err := Load("./testdata/task-descriptions")
 	assert.NoError(t, err)
 
 	metas := map[string]TaskMetadata{}
-	for id, t := range ts {
-		metas[id] = t.Metadata()
+	for _, id := range ts.IDs() {
+		metas[id] = ts.Get(id).Metadata()
 	}
 
 	fPath := "./testdata/task-descriptions/out.log"
@@ -0,0 +1,92 @@
+package run
+
+import "fmt"
+
+// validator holds no state of its own; it exists so the individual
+// checks below can be added to without growing Tasks.Validate's
+// signature, and so a future check that needs shared scratch state
+// (e.g. a memoized glob expansion) has somewhere to keep it.
+type validator struct{}
+
+func newValidator() *validator {
+	return &validator{}
+}
+
+// validate runs every structural and timing check over ts, returning the
+// first error encountered. Checks run in a fixed order - Type, then
+// Dependencies/Triggers, then timing, for each task in turn, and then
+// dependency cycles across all tasks - so a given invalid Tasks always
+// fails the same way.
+func (v *validator) validate(ts Tasks) error {
+	for _, id := range ts.IDs() {
+		meta := ts.Get(id).Metadata()
+
+		if meta.Type != "short" && meta.Type != "long" {
+			return fmt.Errorf("task %s: Type must be \"short\" or \"long\", got %q", id, meta.Type)
+		}
+
+		for _, dep := range meta.Dependencies {
+			if !ts.Has(dep) {
+				return fmt.Errorf("task %s: dependency %q does not exist", id, dep)
+			}
+		}
+
+		for _, trig := range meta.Triggers {
+			trigger := ts.Get(trig)
+			if trigger == nil {
+				return fmt.Errorf("task %s: trigger %q does not exist", id, trig)
+			}
+			if trigger.Metadata().Type == "long" {
+				return fmt.Errorf("task %s: trigger %q is a long task, which cannot be used as a trigger", id, trig)
+			}
+		}
+
+		if err := validateTiming(meta); err != nil {
+			return err
+		}
+	}
+
+	return validateNoDependencyCycle(ts)
+}
+
+// validateNoDependencyCycle reports the first cycle found among tasks'
+// Dependencies edges, using the same three-state visit resolveExtends
+// uses for `extends` edges: a Dependencies cycle would otherwise send
+// Run into an infinite "start this task's dependency first" recursion.
+func validateNoDependencyCycle(ts Tasks) error {
+	const (
+		unvisited = 0
+		visiting  = 1
+		resolved  = 2
+	)
+	state := make(map[string]int, len(ts.IDs()))
+
+	var visit func(id string, chain []string) error
+	visit = func(id string, chain []string) error {
+		switch state[id] {
+		case resolved:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s -> %s", joinChain(chain), id)
+		}
+		t := ts.Get(id)
+		if t == nil {
+			return nil
+		}
+		state[id] = visiting
+		for _, dep := range t.Metadata().Dependencies {
+			if err := visit(dep, append(chain, id)); err != nil {
+				return err
+			}
+		}
+		state[id] = resolved
+		return nil
+	}
+
+	for _, id := range ts.IDs() {
+		if err := visit(id, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package run
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopTask struct{ meta TaskMetadata }
+
+func (n noopTask) Start(ctx context.Context, stdout io.Writer) error { return nil }
+func (n noopTask) Metadata() TaskMetadata                            { return n.meta }
+
+func TestValidateRejectsUnknownType(t *testing.T) {
+	ts := NewTasks([]Task{noopTask{meta: TaskMetadata{ID: "build", Type: "medium"}}})
+	err := ts.Validate()
+	assert.ErrorContains(t, err, "Type")
+}
+
+func TestValidateRejectsMissingDependency(t *testing.T) {
+	ts := NewTasks([]Task{noopTask{meta: TaskMetadata{ID: "build", Type: "short", Dependencies: []string{"missing"}}}})
+	err := ts.Validate()
+	assert.ErrorContains(t, err, "missing")
+}
+
+func TestValidateRejectsLongTaskAsTrigger(t *testing.T) {
+	ts := NewTasks([]Task{
+		noopTask{meta: TaskMetadata{ID: "server", Type: "long"}},
+		noopTask{meta: TaskMetadata{ID: "build", Type: "short", Triggers: []string{"server"}}},
+	})
+	err := ts.Validate()
+	assert.ErrorContains(t, err, "long task")
+}
+
+func TestValidateRejectsDependencyCycle(t *testing.T) {
+	ts := NewTasks([]Task{
+		noopTask{meta: TaskMetadata{ID: "a", Type: "short", Dependencies: []string{"b"}}},
+		noopTask{meta: TaskMetadata{ID: "b", Type: "short", Dependencies: []string{"a"}}},
+	})
+	err := ts.Validate()
+	assert.ErrorContains(t, err, "cycle")
+}
+
+func TestValidateAcceptsWellFormedTasks(t *testing.T) {
+	ts := NewTasks([]Task{
+		noopTask{meta: TaskMetadata{ID: "build", Type: "short", Dependencies: []string{"deps"}}},
+		noopTask{meta: TaskMetadata{ID: "deps", Type: "short"}},
+	})
+	require.NoError(t, ts.Validate())
+}